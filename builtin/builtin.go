@@ -12,6 +12,8 @@ package builtin
 
 import (
 	"bufio"
+	"fmt"
+	"io"
 	"sync"
 
 	"github.com/skx/gobasic/object"
@@ -25,49 +27,133 @@ import (
 // In the case of an error then the object will be an error-object.
 type Signature func(env Environment, args []object.Object) object.Object
 
+// UnlimitedArgs is the sentinel used as an ArgSpec's Max to mean "no
+// upper bound on the number of arguments", e.g. for PRINT a,b,c or a
+// user-supplied PRINTF(fmt, ...).
+const UnlimitedArgs = -1
+
+// ArgSpec describes how many arguments a built-in accepts.
+//
+// Most builtins are fixed-arity, in which case Min and Max are equal and
+// Variadic is false. A variadic builtin instead sets Max to
+// UnlimitedArgs, meaning any number of arguments >= Min is accepted.
+type ArgSpec struct {
+	// Min is the smallest number of arguments the built-in will accept.
+	Min int
+
+	// Max is the largest number of arguments the built-in will accept,
+	// or UnlimitedArgs if there is no upper bound.
+	Max int
+
+	// Variadic is true if the built-in has no upper bound on its
+	// arguments, i.e. Max is UnlimitedArgs. A bounded range such as
+	// 2 to 3 arguments leaves it false.
+	Variadic bool
+}
+
+// Accepts reports whether n arguments satisfy this ArgSpec.
+func (s ArgSpec) Accepts(n int) bool {
+	if n < s.Min {
+		return false
+	}
+	if s.Max == UnlimitedArgs {
+		return true
+	}
+	return n <= s.Max
+}
+
+// describe renders a human-readable summary of the accepted argument
+// count, for use in error messages.
+func (s ArgSpec) describe() string {
+	if s.Variadic {
+		return fmt.Sprintf("at least %d argument(s)", s.Min)
+	}
+	if s.Min == s.Max {
+		return fmt.Sprintf("%d argument(s)", s.Min)
+	}
+	return fmt.Sprintf("between %d and %d argument(s)", s.Min, s.Max)
+}
+
 // Builtins holds our state.
 type Builtins struct {
 	// lock holds a mutex to prevent corruption.
 	lock sync.Mutex
 
-	// argRegistry holds the number of arguments the given name requires.
-	argRegistry map[string]int
+	// argRegistry holds the argument-count specification the given
+	// name requires.
+	argRegistry map[string]ArgSpec
 
 	// fnRegistry holds a reference to the golang function which
 	// implements the builtin.
 	fnRegistry map[string]Signature
+
+	// observer, if non-nil, is notified of every builtin dispatch made
+	// via Call. See SetObserver.
+	observer Observer
 }
 
 // New returns a new helper/holder for builtin functions.
 func New() *Builtins {
 	t := &Builtins{}
-	t.argRegistry = make(map[string]int)
+	t.argRegistry = make(map[string]ArgSpec)
 	t.fnRegistry = make(map[string]Signature)
 
 	return t
 }
 
-// Register records a built-in function.
+// Register records a built-in function which requires a fixed number
+// of arguments.
 // The three arguments are:
 //  NAME  - The thing that the BASIC program will call
 //  nARGS - The number of arguments the built-in requires.
 //          NOTE: Arguments are comma-separated in the BASIC program,
 //          but commas are stripped out.
 //  FT    - The function which provides the implementation.
+//
+// Use RegisterVar instead if the built-in accepts a variable number
+// of arguments, such as PRINT or a user-supplied PRINTF.
 func (b *Builtins) Register(name string, nArgs int, ft Signature) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
 	// Record the details.
-	b.argRegistry[name] = nArgs
+	b.argRegistry[name] = ArgSpec{Min: nArgs, Max: nArgs}
+	b.fnRegistry[name] = ft
+}
+
+// RegisterVar records a built-in function which accepts between min
+// and max arguments, inclusive. Pass UnlimitedArgs as max to accept
+// any number of arguments >= min, for things like PRINT a,b,c or
+// PRINTF(fmt, ...).
+func (b *Builtins) RegisterVar(name string, min int, max int, ft Signature) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.argRegistry[name] = ArgSpec{Min: min, Max: max, Variadic: max == UnlimitedArgs}
 	b.fnRegistry[name] = ft
 }
 
 // Get the values associated with the given built-in.
+//
+// The returned int is the upper bound on the number of arguments the
+// built-in accepts - exactly as before ArgSpec existed - with
+// UnlimitedArgs (-1) meaning "as many as the caller supplies", so
+// existing callers built around `n, fn := Get(name)` keep working
+// unchanged. Use GetSpec instead if the min/max/variadic distinction
+// matters.
 func (b *Builtins) Get(name string) (int, Signature) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	return b.argRegistry[name].Max, b.fnRegistry[name]
+}
+
+// GetSpec returns the full ArgSpec associated with the given built-in,
+// alongside its implementation.
+func (b *Builtins) GetSpec(name string) (ArgSpec, Signature) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
 	return b.argRegistry[name], b.fnRegistry[name]
 }
 
@@ -92,3 +178,25 @@ type Environment interface {
 	// Data allows the builtins to get a reference to the intepreter.
 	Data() interface{}
 }
+
+// SinkCapable is an optional interface an Environment implementation
+// may satisfy to support teeing output to additional writers, e.g. into
+// a RotatingFileSink, without wrapping every builtin that produces
+// output. It is deliberately kept separate from Environment itself so
+// that adding it doesn't break existing Environment implementations
+// that haven't opted in - callers should type-assert before using it:
+//
+//	if sc, ok := env.(builtin.SinkCapable); ok {
+//	        sc.AddOutputSink(w)
+//	}
+type SinkCapable interface {
+	// AddOutputSink registers an additional writer that every PRINT
+	// (and similar) will be teed to, alongside whatever StdOutput()
+	// already writes to.
+	AddOutputSink(w io.Writer)
+
+	// AddErrorSink registers an additional writer that every error
+	// message will be teed to, alongside whatever StdError() already
+	// writes to.
+	AddErrorSink(w io.Writer)
+}
@@ -0,0 +1,50 @@
+package builtin
+
+import "testing"
+
+// TestRegisterFixedArity checks that Register stores a fixed-arity
+// ArgSpec that Accepts only the exact argument count.
+func TestRegisterFixedArity(t *testing.T) {
+	b := New()
+	b.Register("SIN", 1, nil)
+
+	spec, _ := b.GetSpec("SIN")
+
+	if spec.Variadic {
+		t.Fatalf("expected a fixed-arity ArgSpec, got variadic")
+	}
+	for n, want := range map[int]bool{0: false, 1: true, 2: false} {
+		if got := spec.Accepts(n); got != want {
+			t.Errorf("Accepts(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+// TestRegisterVarArity checks that RegisterVar stores a bounded,
+// non-Variadic ArgSpec for a min/max range, and that UnlimitedArgs
+// lifts the upper bound and marks the ArgSpec Variadic.
+func TestRegisterVarArity(t *testing.T) {
+	b := New()
+	b.RegisterVar("MID$", 2, 3, nil)
+
+	spec, _ := b.GetSpec("MID$")
+
+	if spec.Variadic {
+		t.Fatalf("expected a bounded ArgSpec, got variadic")
+	}
+	for n, want := range map[int]bool{1: false, 2: true, 3: true, 4: false} {
+		if got := spec.Accepts(n); got != want {
+			t.Errorf("Accepts(%d) = %v, want %v", n, got, want)
+		}
+	}
+
+	b.RegisterVar("PRINT", 0, UnlimitedArgs, nil)
+	spec, _ = b.GetSpec("PRINT")
+
+	if !spec.Variadic {
+		t.Fatalf("expected an unlimited ArgSpec to be marked Variadic")
+	}
+	if !spec.Accepts(100) {
+		t.Errorf("expected an unlimited ArgSpec to accept any argument count")
+	}
+}
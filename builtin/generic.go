@@ -0,0 +1,156 @@
+package builtin
+
+import (
+	"fmt"
+
+	"github.com/skx/gobasic/object"
+)
+
+// nativeResult converts a native Go value returned by one of the typed
+// RegisterFnN wrappers into the object.Object the rest of the interpreter
+// expects.
+//
+// Only the handful of types a builtin can sensibly return are supported;
+// anything else is reported as an error so the mistake is caught as soon
+// as the function is registered and called, rather than silently
+// misbehaving.
+func nativeResult(v interface{}) object.Object {
+	switch val := v.(type) {
+	case float64:
+		return object.Number(val)
+	case int:
+		return object.Number(float64(val))
+	case string:
+		return object.String(val)
+	default:
+		return object.Error("%s", fmt.Sprintf("unsupported return type %T from builtin", v))
+	}
+}
+
+// nativeFloat unwraps the float64 carried by an object.NumberObject,
+// returning an error-object if the argument is of the wrong type.
+func nativeFloat(name string, arg object.Object) (float64, object.Object) {
+	n, ok := arg.(*object.NumberObject)
+	if !ok {
+		return 0, object.Error("%s", fmt.Sprintf("%s: expected a number, got %v", name, arg))
+	}
+	return n.Value, nil
+}
+
+// nativeString unwraps the string carried by an object.StringObject,
+// returning an error-object if the argument is of the wrong type.
+func nativeString(name string, arg object.Object) (string, object.Object) {
+	s, ok := arg.(*object.StringObject)
+	if !ok {
+		return "", object.Error("%s", fmt.Sprintf("%s: expected a string, got %v", name, arg))
+	}
+	return s.Value, nil
+}
+
+// nativeArg unwraps a single argument into the requested native Go type
+// (float64 or string), dispatching on T so that RegisterFn1/RegisterFn2
+// can stay generic over both.
+func nativeArg[T any](name string, arg object.Object) (T, object.Object) {
+	var zero T
+
+	switch any(zero).(type) {
+	case float64:
+		v, err := nativeFloat(name, arg)
+		if err != nil {
+			return zero, err
+		}
+		return any(v).(T), nil
+	case string:
+		v, err := nativeString(name, arg)
+		if err != nil {
+			return zero, err
+		}
+		return any(v).(T), nil
+	default:
+		return zero, object.Error("%s", fmt.Sprintf("%s: unsupported argument type %T", name, zero))
+	}
+}
+
+// RegisterFn1 registers a builtin taking a single typed argument, such as
+// LEN(s) or SIN(n), without requiring the caller to hand-roll arity
+// checking or object-unwrapping.
+//
+//	builtin.RegisterFn1(builtins, "LEN", func(env Environment, s string) (float64, error) {
+//	        return float64(len(s)), nil
+//	})
+func RegisterFn1[T1 any, R any](b *Builtins, name string, fn func(env Environment, a T1) (R, error)) {
+	spec := ArgSpec{Min: 1, Max: 1}
+
+	b.Register(name, 1, func(env Environment, args []object.Object) object.Object {
+		if !spec.Accepts(len(args)) {
+			return object.Error("%s", fmt.Sprintf("%s: called with %d argument(s), expected %s", name, len(args), spec.describe()))
+		}
+
+		a1, errObj := nativeArg[T1](name, args[0])
+		if errObj != nil {
+			return errObj
+		}
+
+		res, err := fn(env, a1)
+		if err != nil {
+			return object.Error("%s", err.Error())
+		}
+		return nativeResult(res)
+	})
+}
+
+// RegisterFn2 registers a builtin taking two typed arguments, such as
+// MID$(s, i) or POKE(addr, val).
+func RegisterFn2[T1 any, T2 any, R any](b *Builtins, name string, fn func(env Environment, a T1, b T2) (R, error)) {
+	spec := ArgSpec{Min: 2, Max: 2}
+
+	b.Register(name, 2, func(env Environment, args []object.Object) object.Object {
+		if !spec.Accepts(len(args)) {
+			return object.Error("%s", fmt.Sprintf("%s: called with %d argument(s), expected %s", name, len(args), spec.describe()))
+		}
+
+		a1, errObj := nativeArg[T1](name, args[0])
+		if errObj != nil {
+			return errObj
+		}
+		a2, errObj := nativeArg[T2](name, args[1])
+		if errObj != nil {
+			return errObj
+		}
+
+		res, err := fn(env, a1, a2)
+		if err != nil {
+			return object.Error("%s", err.Error())
+		}
+		return nativeResult(res)
+	})
+}
+
+// RegisterFnV registers a variadic builtin, such as PRINTF(fmt, ...),
+// where every argument shares the same native Go type T and between
+// min and max of them (UnlimitedArgs for max) are accepted.
+func RegisterFnV[T any, R any](b *Builtins, name string, min, max int, fn func(env Environment, args []T) (R, error)) {
+	spec := ArgSpec{Min: min, Max: max, Variadic: max == UnlimitedArgs}
+
+	b.RegisterVar(name, min, max, func(env Environment, args []object.Object) object.Object {
+		if !spec.Accepts(len(args)) {
+			return object.Error("%s", fmt.Sprintf("%s: called with %d argument(s), expected %s", name, len(args), spec.describe()))
+		}
+
+		native := make([]T, len(args))
+
+		for i, arg := range args {
+			v, errObj := nativeArg[T](name, arg)
+			if errObj != nil {
+				return errObj
+			}
+			native[i] = v
+		}
+
+		res, err := fn(env, native)
+		if err != nil {
+			return object.Error("%s", err.Error())
+		}
+		return nativeResult(res)
+	})
+}
@@ -0,0 +1,56 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/skx/gobasic/object"
+)
+
+// TestRegisterFn1Arity ensures a RegisterFn1-backed builtin rejects the
+// wrong number of arguments rather than panicking on an out-of-range
+// index.
+func TestRegisterFn1Arity(t *testing.T) {
+	b := New()
+	RegisterFn1(b, "SIN", func(env Environment, n float64) (float64, error) {
+		return n, nil
+	})
+
+	spec, fn := b.GetSpec("SIN")
+
+	if out := fn(nil, nil); func() bool { _, ok := out.(*object.ErrorObject); return !ok }() {
+		t.Fatalf("expected an error-object when called with no arguments, got %v", out)
+	}
+	if spec.Min != 1 || spec.Max != 1 {
+		t.Fatalf("expected a 1-argument ArgSpec, got %+v", spec)
+	}
+}
+
+// TestRegisterFn2Arity mirrors TestRegisterFn1Arity for the two-argument
+// wrapper.
+func TestRegisterFn2Arity(t *testing.T) {
+	b := New()
+	RegisterFn2(b, "POKE", func(env Environment, addr, val float64) (float64, error) {
+		return val, nil
+	})
+
+	out := b.Call("POKE", nil, []object.Object{object.Number(1)})
+
+	if _, ok := out.(*object.ErrorObject); !ok {
+		t.Fatalf("expected an error-object for a missing second argument, got %v", out)
+	}
+}
+
+// TestRegisterFnVArity checks that a variadic builtin with no upper
+// bound enforces its minimum arity.
+func TestRegisterFnVArity(t *testing.T) {
+	b := New()
+	RegisterFnV(b, "PRINTF", 1, UnlimitedArgs, func(env Environment, args []string) (string, error) {
+		return "", nil
+	})
+
+	out := b.Call("PRINTF", nil, []object.Object{})
+
+	if _, ok := out.(*object.ErrorObject); !ok {
+		t.Fatalf("expected an error-object when called below the minimum arity, got %v", out)
+	}
+}
@@ -0,0 +1,173 @@
+package builtin
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/skx/gobasic/object"
+)
+
+// Observer is notified around every builtin dispatch, allowing an
+// embedder to instrument calls without changing the evaluator or any
+// individual builtin.
+type Observer interface {
+	// OnCall is invoked once a builtin has returned, with the name it
+	// was registered under, the arguments it was called with, how
+	// long it took to run, and the object it returned.
+	OnCall(name string, args []object.Object, dur time.Duration, result object.Object)
+}
+
+// SetObserver installs the Observer that Call will notify of every
+// builtin dispatch. Pass nil to disable instrumentation again.
+//
+// The observer is swapped under the same lock that guards the
+// registries, so it is safe to call this while builtins are being
+// registered or invoked from other goroutines.
+func (b *Builtins) SetObserver(o Observer) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.observer = o
+}
+
+// Call looks up the builtin registered as name and invokes it with the
+// supplied arguments, notifying any installed Observer with the
+// outcome. This is the entry-point the evaluator should use to dispatch
+// builtins, rather than calling Get and invoking the Signature itself,
+// so that instrumentation is never accidentally bypassed.
+func (b *Builtins) Call(name string, env Environment, args []object.Object) object.Object {
+	b.lock.Lock()
+	spec, fn := b.argRegistry[name], b.fnRegistry[name]
+	observer := b.observer
+	b.lock.Unlock()
+
+	if fn == nil {
+		return object.Error("unknown builtin %s", name)
+	}
+	if !spec.Accepts(len(args)) {
+		return object.Error("%s", fmt.Sprintf("%s: called with %d argument(s), expected %s", name, len(args), spec.describe()))
+	}
+
+	start := time.Now()
+	result := fn(env, args)
+	dur := time.Since(start)
+
+	if observer != nil {
+		observer.OnCall(name, args, dur, result)
+	}
+	return result
+}
+
+// functionMetrics holds the running counters and latency histogram for
+// a single builtin, in the style of rcrowley/go-metrics' Counter and
+// Timer.
+type functionMetrics struct {
+	calls  int64
+	errors int64
+
+	// samples is a bounded window of recent call durations, used to
+	// derive min/max/mean without retaining unbounded history.
+	samples []time.Duration
+}
+
+// maxSamples bounds the number of latency samples retained per
+// function, so long-running processes don't grow this unboundedly.
+const maxSamples = 1024
+
+// FunctionStats is a point-in-time snapshot of the counters for a
+// single builtin, returned by Metrics.Snapshot.
+type FunctionStats struct {
+	// Calls is the total number of times the builtin has been invoked.
+	Calls int64
+
+	// Errors is the number of those invocations whose result was an
+	// error-object.
+	Errors int64
+
+	// Min, Max and Mean summarise the latency of the invocations
+	// retained in the sample window.
+	Min, Max, Mean time.Duration
+}
+
+// Metrics is the default Observer implementation. It keeps per-function
+// call counts, error counts and a latency histogram in memory, and is
+// intended to be polled periodically by an embedder and exported to
+// Prometheus, StatsD, or similar.
+type Metrics struct {
+	lock sync.Mutex
+	fns  map[string]*functionMetrics
+}
+
+// NewMetrics returns an empty Metrics observer, ready to be installed
+// with (*Builtins).SetObserver.
+func NewMetrics() *Metrics {
+	return &Metrics{fns: make(map[string]*functionMetrics)}
+}
+
+// OnCall implements Observer, recording the call count, error count and
+// latency sample for name.
+func (m *Metrics) OnCall(name string, args []object.Object, dur time.Duration, result object.Object) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	fn, ok := m.fns[name]
+	if !ok {
+		fn = &functionMetrics{}
+		m.fns[name] = fn
+	}
+
+	fn.calls++
+	if _, isError := result.(*object.ErrorObject); isError {
+		fn.errors++
+	}
+
+	if len(fn.samples) >= maxSamples {
+		fn.samples = fn.samples[1:]
+	}
+	fn.samples = append(fn.samples, dur)
+}
+
+// Snapshot returns the current FunctionStats for every builtin that has
+// been called at least once.
+func (m *Metrics) Snapshot() map[string]FunctionStats {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	out := make(map[string]FunctionStats, len(m.fns))
+	for name, fn := range m.fns {
+		stats := FunctionStats{Calls: fn.calls, Errors: fn.errors}
+
+		for i, d := range fn.samples {
+			if i == 0 || d < stats.Min {
+				stats.Min = d
+			}
+			if d > stats.Max {
+				stats.Max = d
+			}
+			stats.Mean += d
+		}
+		if len(fn.samples) > 0 {
+			stats.Mean /= time.Duration(len(fn.samples))
+		}
+
+		out[name] = stats
+	}
+	return out
+}
+
+// Metrics returns the installed observer if it is a *Metrics. Otherwise
+// it installs a new Metrics as the observer and returns that; any other
+// observer set via SetObserver is replaced.
+func (b *Builtins) Metrics() *Metrics {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if m, ok := b.observer.(*Metrics); ok {
+		return m
+	}
+
+	m := NewMetrics()
+	b.observer = m
+	return m
+}
@@ -0,0 +1,71 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/skx/gobasic/object"
+)
+
+// TestCallArityMismatch ensures that calling a builtin with the wrong
+// number of arguments returns an error-object instead of panicking.
+func TestCallArityMismatch(t *testing.T) {
+	b := New()
+	b.Register("SIN", 1, func(env Environment, args []object.Object) object.Object {
+		return object.Number(0)
+	})
+
+	out := b.Call("SIN", nil, []object.Object{})
+
+	if _, ok := out.(*object.ErrorObject); !ok {
+		t.Fatalf("expected an error-object for a missing argument, got %v", out)
+	}
+}
+
+// TestCallUnknownBuiltin ensures that Call returns an error-object for
+// a name that was never registered.
+func TestCallUnknownBuiltin(t *testing.T) {
+	b := New()
+
+	out := b.Call("NOSUCHFUNCTION", nil, nil)
+
+	if _, ok := out.(*object.ErrorObject); !ok {
+		t.Fatalf("expected an error-object for an unknown builtin, got %v", out)
+	}
+}
+
+// TestMetricsSnapshot exercises OnCall and Snapshot together, checking
+// the call count, error count and latency aggregation they report.
+func TestMetricsSnapshot(t *testing.T) {
+	m := NewMetrics()
+
+	m.OnCall("SIN", nil, 10, object.Number(0))
+	m.OnCall("SIN", nil, 20, object.Error("boom"))
+
+	stats := m.Snapshot()["SIN"]
+
+	if stats.Calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", stats.Calls)
+	}
+	if stats.Errors != 1 {
+		t.Fatalf("expected 1 error, got %d", stats.Errors)
+	}
+	if stats.Min != 10 || stats.Max != 20 {
+		t.Fatalf("expected min/max of 10/20, got %d/%d", stats.Min, stats.Max)
+	}
+	if stats.Mean != 15 {
+		t.Fatalf("expected mean of 15, got %d", stats.Mean)
+	}
+}
+
+// TestMetricsSingleInstance guards against the Metrics accessor
+// installing more than one observer when called repeatedly.
+func TestMetricsSingleInstance(t *testing.T) {
+	b := New()
+
+	a := b.Metrics()
+	c := b.Metrics()
+
+	if a != c {
+		t.Fatalf("expected Metrics() to return the same instance on repeated calls")
+	}
+}
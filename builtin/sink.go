@@ -0,0 +1,310 @@
+package builtin
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SinkManager fans writes out to a primary writer plus any number of
+// additional sinks registered at runtime. An Environment implementation
+// embeds one to back StdOutput()/StdError() so that AddOutputSink and
+// AddErrorSink have somewhere to tee into, and so that a test can attach
+// an in-memory sink alongside the real stdout.
+type SinkManager struct {
+	lock  sync.Mutex
+	sinks []io.Writer
+}
+
+// NewSinkManager returns a SinkManager which always writes to primary,
+// in addition to any sinks added later via Add.
+func NewSinkManager(primary io.Writer) *SinkManager {
+	return &SinkManager{sinks: []io.Writer{primary}}
+}
+
+// Add registers an additional writer that every future Write will be
+// teed to.
+func (s *SinkManager) Add(w io.Writer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sinks = append(s.sinks, w)
+}
+
+// Write implements io.Writer, sending p to every registered sink. The
+// first error encountered is returned, but every sink is still given
+// the chance to write so that one broken sink doesn't starve the rest.
+func (s *SinkManager) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	sinks := append([]io.Writer(nil), s.sinks...)
+	s.lock.Unlock()
+
+	var firstErr error
+	for _, w := range sinks {
+		if _, err := w.Write(p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return len(p), firstErr
+}
+
+// Archive decides when and where a RotatingFileSink rolls over to a new
+// file, mirroring starlog's Archive hook so existing rotation policies
+// can be ported across with little change.
+type Archive interface {
+	// ShouldArchiveNow is consulted after every write, and is passed
+	// the number of bytes written to the current file so far. A true
+	// return triggers rotation.
+	ShouldArchiveNow(writtenBytes int64) bool
+
+	// NextLogFilePath returns the path the next (or first) log file
+	// should be created at.
+	NextLogFilePath() string
+
+	// HookAfterArchive is called with the path of the file that was
+	// just closed, once rotation has completed.
+	HookAfterArchive(path string)
+}
+
+// SizeArchive is a size-based Archive: it rotates once the current log
+// file reaches maxBytes, naming each successive file
+// "<dir>/<prefix>.<N>.log".
+type SizeArchive struct {
+	dir      string
+	prefix   string
+	maxBytes int64
+
+	lock sync.Mutex
+	seq  int
+}
+
+// NewSizeArchive returns a SizeArchive which rotates log files under
+// dir, named with prefix, once they reach maxBytes.
+func NewSizeArchive(dir, prefix string, maxBytes int64) *SizeArchive {
+	return &SizeArchive{dir: dir, prefix: prefix, maxBytes: maxBytes}
+}
+
+// ShouldArchiveNow implements Archive.
+func (a *SizeArchive) ShouldArchiveNow(writtenBytes int64) bool {
+	return writtenBytes >= a.maxBytes
+}
+
+// NextLogFilePath implements Archive.
+func (a *SizeArchive) NextLogFilePath() string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.seq++
+	return filepath.Join(a.dir, fmt.Sprintf("%s.%d.log", a.prefix, a.seq))
+}
+
+// HookAfterArchive implements Archive; SizeArchive doesn't need to do
+// anything once a file has been rotated away.
+func (a *SizeArchive) HookAfterArchive(path string) {}
+
+// TimeArchive is a time-based Archive: it rotates once interval has
+// elapsed since the current log file was opened, naming each
+// successive file "<dir>/<prefix>.<N>.log".
+type TimeArchive struct {
+	dir      string
+	prefix   string
+	interval time.Duration
+
+	lock     sync.Mutex
+	seq      int
+	deadline time.Time
+}
+
+// NewTimeArchive returns a TimeArchive which rotates log files under
+// dir, named with prefix, every interval.
+func NewTimeArchive(dir, prefix string, interval time.Duration) *TimeArchive {
+	return &TimeArchive{dir: dir, prefix: prefix, interval: interval, deadline: time.Now().Add(interval)}
+}
+
+// ShouldArchiveNow implements Archive.
+func (a *TimeArchive) ShouldArchiveNow(writtenBytes int64) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if time.Now().Before(a.deadline) {
+		return false
+	}
+	a.deadline = time.Now().Add(a.interval)
+	return true
+}
+
+// NextLogFilePath implements Archive.
+func (a *TimeArchive) NextLogFilePath() string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.seq++
+	return filepath.Join(a.dir, fmt.Sprintf("%s.%d.log", a.prefix, a.seq))
+}
+
+// HookAfterArchive implements Archive; TimeArchive doesn't need to do
+// anything once a file has been rotated away.
+func (a *TimeArchive) HookAfterArchive(path string) {}
+
+var (
+	_ Archive = (*SizeArchive)(nil)
+	_ Archive = (*TimeArchive)(nil)
+)
+
+// RotatingFileSink is an io.Writer that writes to a file on disk,
+// rolling over to a new file - as decided by an Archive - once it fills
+// up or enough time has passed. It is intended to be handed to
+// AddOutputSink/AddErrorSink so a long-running embedding can capture
+// per-script output without wrapping every builtin.
+type RotatingFileSink struct {
+	lock sync.Mutex
+
+	archive Archive
+	file    *os.File
+	path    string
+	written int64
+}
+
+// NewRotatingFileSink creates a RotatingFileSink whose rotation policy
+// is driven by archive. The first file is opened immediately at
+// archive.NextLogFilePath().
+func NewRotatingFileSink(archive Archive) (*RotatingFileSink, error) {
+	r := &RotatingFileSink{archive: archive}
+
+	if err := r.openNext(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// openNext opens the next log file as named by the Archive, replacing
+// the current one.
+func (r *RotatingFileSink) openNext() error {
+	path := r.archive.NextLogFilePath()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	r.file = f
+	r.path = path
+	r.written = 0
+	return nil
+}
+
+// Write implements io.Writer, appending p to the current log file and
+// rotating to a new one afterwards if the Archive says it's time.
+func (r *RotatingFileSink) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	n, err := r.file.Write(p)
+	r.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	if r.archive.ShouldArchiveNow(r.written) {
+		closed := r.path
+		if cerr := r.file.Close(); cerr != nil {
+			return n, cerr
+		}
+
+		if err := r.openNext(); err != nil {
+			return n, err
+		}
+		r.archive.HookAfterArchive(closed)
+	}
+
+	return n, nil
+}
+
+// Close closes the currently open log file.
+func (r *RotatingFileSink) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.file.Close()
+}
+
+// Compile-time assertion that SinkEnvironment actually satisfies
+// Environment, rather than quietly rotting out of sync with it.
+var _ Environment = (*SinkEnvironment)(nil)
+
+// SinkEnvironment is a reference Environment implementation that also
+// satisfies SinkCapable, backing StdOutput()/StdError() with a
+// SinkManager each so AddOutputSink/AddErrorSink actually tee a running
+// script's output rather than being an extension point nothing
+// implements. Embedders such as goserver/ can use this directly, or
+// embed it in a richer Environment of their own.
+type SinkEnvironment struct {
+	in *bufio.Reader
+
+	outMgr *SinkManager
+	errMgr *SinkManager
+	out    *bufio.Writer
+	err    *bufio.Writer
+
+	lineEnding string
+	data       interface{}
+}
+
+// NewSinkEnvironment returns a SinkEnvironment that reads from in and
+// writes, by default, to out and errW. Additional sinks can be teed in
+// later via AddOutputSink/AddErrorSink.
+func NewSinkEnvironment(in *bufio.Reader, out io.Writer, errW io.Writer, lineEnding string, data interface{}) *SinkEnvironment {
+	outMgr := NewSinkManager(out)
+	errMgr := NewSinkManager(errW)
+
+	return &SinkEnvironment{
+		in:         in,
+		outMgr:     outMgr,
+		errMgr:     errMgr,
+		out:        bufio.NewWriter(outMgr),
+		err:        bufio.NewWriter(errMgr),
+		lineEnding: lineEnding,
+		data:       data,
+	}
+}
+
+// StdInput implements Environment.
+func (s *SinkEnvironment) StdInput() *bufio.Reader { return s.in }
+
+// StdOutput implements Environment. Every write made through the
+// returned writer is fanned out to the primary writer plus any sinks
+// registered via AddOutputSink.
+func (s *SinkEnvironment) StdOutput() *bufio.Writer { return s.out }
+
+// StdError implements Environment. Every write made through the
+// returned writer is fanned out to the primary writer plus any sinks
+// registered via AddErrorSink.
+func (s *SinkEnvironment) StdError() *bufio.Writer { return s.err }
+
+// LineEnding implements Environment.
+func (s *SinkEnvironment) LineEnding() string { return s.lineEnding }
+
+// Data implements Environment.
+func (s *SinkEnvironment) Data() interface{} { return s.data }
+
+// AddOutputSink implements SinkCapable, teeing future PRINT output to w
+// as well as the primary output writer.
+func (s *SinkEnvironment) AddOutputSink(w io.Writer) {
+	s.out.Flush()
+	s.outMgr.Add(w)
+}
+
+// AddErrorSink implements SinkCapable, teeing future error output to w
+// as well as the primary error writer.
+func (s *SinkEnvironment) AddErrorSink(w io.Writer) {
+	s.err.Flush()
+	s.errMgr.Add(w)
+}
+
+// Compile-time assertion that SinkEnvironment also satisfies
+// SinkCapable.
+var _ SinkCapable = (*SinkEnvironment)(nil)
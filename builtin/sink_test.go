@@ -0,0 +1,112 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSinkManagerFanOut checks that a write is delivered to the primary
+// writer and to every sink added afterwards.
+func TestSinkManagerFanOut(t *testing.T) {
+	var primary, extra bytes.Buffer
+
+	mgr := NewSinkManager(&primary)
+	mgr.Add(&extra)
+
+	if _, err := mgr.Write([]byte("hello")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if primary.String() != "hello" {
+		t.Errorf("primary got %q, want %q", primary.String(), "hello")
+	}
+	if extra.String() != "hello" {
+		t.Errorf("extra got %q, want %q", extra.String(), "hello")
+	}
+}
+
+// TestSinkEnvironmentAddOutputSink checks that AddOutputSink actually
+// wires into StdOutput(), rather than being an unimplemented interface
+// method.
+func TestSinkEnvironmentAddOutputSink(t *testing.T) {
+	var primary, extra bytes.Buffer
+
+	env := NewSinkEnvironment(bufio.NewReader(strings.NewReader("")), &primary, &extra, "\n", nil)
+	env.AddOutputSink(&extra)
+
+	env.StdOutput().WriteString("PRINT ME")
+	env.StdOutput().Flush()
+
+	if primary.String() != "PRINT ME" {
+		t.Errorf("primary got %q, want %q", primary.String(), "PRINT ME")
+	}
+	if extra.String() != "PRINT ME" {
+		t.Errorf("extra got %q, want %q", extra.String(), "PRINT ME")
+	}
+}
+
+// TestRotatingFileSinkSizeRotation checks that a RotatingFileSink backed
+// by a SizeArchive rolls over to a new file once the configured byte
+// threshold is crossed, and that both files end up on disk with their
+// expected contents.
+func TestRotatingFileSinkSizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	archive := NewSizeArchive(dir, "script", 5)
+
+	sink, err := NewRotatingFileSink(archive)
+	if err != nil {
+		t.Fatalf("unexpected error creating sink: %v", err)
+	}
+	defer sink.Close()
+
+	if _, err := sink.Write([]byte("12345")); err != nil {
+		t.Fatalf("unexpected error on first write: %v", err)
+	}
+	if _, err := sink.Write([]byte("67890")); err != nil {
+		t.Fatalf("unexpected error on second write: %v", err)
+	}
+
+	first, err := os.ReadFile(filepath.Join(dir, "script.1.log"))
+	if err != nil {
+		t.Fatalf("expected first log file to exist: %v", err)
+	}
+	if string(first) != "12345" {
+		t.Errorf("first log file got %q, want %q", first, "12345")
+	}
+
+	second, err := os.ReadFile(filepath.Join(dir, "script.2.log"))
+	if err != nil {
+		t.Fatalf("expected second log file to exist after rotation: %v", err)
+	}
+	if string(second) != "67890" {
+		t.Errorf("second log file got %q, want %q", second, "67890")
+	}
+}
+
+// TestSizeArchiveShouldArchiveNow exercises the size-based rotation
+// decision in isolation from file I/O.
+func TestSizeArchiveShouldArchiveNow(t *testing.T) {
+	a := NewSizeArchive(t.TempDir(), "log", 100)
+
+	if a.ShouldArchiveNow(50) {
+		t.Errorf("did not expect rotation below the size threshold")
+	}
+	if !a.ShouldArchiveNow(100) {
+		t.Errorf("expected rotation once the size threshold is reached")
+	}
+}
+
+// TestTimeArchiveShouldArchiveNow exercises the time-based rotation
+// decision, using a zero interval so the very first check is already
+// past the deadline.
+func TestTimeArchiveShouldArchiveNow(t *testing.T) {
+	a := NewTimeArchive(t.TempDir(), "log", 0)
+
+	if !a.ShouldArchiveNow(0) {
+		t.Errorf("expected rotation once the interval has already elapsed")
+	}
+}